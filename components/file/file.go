@@ -0,0 +1,307 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package file declares the abstraction every file/object-storage component (AliCloud OSS, AWS
+// S3, ...) implements, so callers can depend on the File interface instead of a concrete backend.
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	// ErrInvalid is returned when a component's config fails validation.
+	ErrInvalid = errors.New("invalid argument")
+	// ErrNotExist is returned when the requested file/object does not exist.
+	ErrNotExist = errors.New("file does not exist")
+)
+
+// FileConfig is the raw per-component configuration passed to File.Init.
+type FileConfig struct {
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// File is the interface every file/object-storage component implements. Callers should depend
+// on this interface rather than a concrete backend type, so a component can be swapped without
+// code changes elsewhere.
+type File interface {
+	// Init parses metadata and establishes the underlying connection(s).
+	Init(ctx context.Context, config *FileConfig) error
+	Put(ctx context.Context, req *PutFileStu) error
+	Get(ctx context.Context, req *GetFileStu) (io.ReadCloser, error)
+	List(ctx context.Context, req *ListRequest) (*ListResp, error)
+	Del(ctx context.Context, req *DelRequest) error
+	Stat(ctx context.Context, req *FileMetaRequest) (*FileMetaResp, error)
+
+	// InitMultipart, UploadPart, CompleteMultipart, AbortMultipart and ListParts are the
+	// low-level multipart upload primitives; PutLarge is the high-level convenience built on
+	// top of them that automatically chunks, uploads concurrently and resumes via a local
+	// checkpoint.
+	InitMultipart(ctx context.Context, req *InitMultipartReq) (uploadID string, err error)
+	UploadPart(ctx context.Context, req *UploadPartReq) (*PartInfo, error)
+	CompleteMultipart(ctx context.Context, req *CompleteMultipartReq) error
+	AbortMultipart(ctx context.Context, req *AbortMultipartReq) error
+	ListParts(ctx context.Context, req *ListPartsReq) ([]*PartInfo, error)
+	PutLarge(ctx context.Context, req *PutFileStu, opts *PutLargeOptions) error
+
+	// SignURL generates a presigned URL so callers can GET/PUT/HEAD/DELETE an object without
+	// proxying bytes through Layotto. SignPostPolicy builds a POST policy document for a
+	// browser to upload directly to the bucket. Both are reachable today only through the
+	// file.File interface directly; this tree has no proto/gRPC file-API layer to extend (there
+	// is no runtime/grpc package here), so exposing them as RPCs is left for whichever service
+	// wires up file.File to the gRPC API.
+	SignURL(ctx context.Context, req *SignURLRequest) (*SignURLResponse, error)
+	SignPostPolicy(ctx context.Context, req *PostPolicyRequest) (*PostPolicyResponse, error)
+
+	// RestoreVersion makes a non-current object version the current object again, for a
+	// version-enabled bucket. Reading a specific version goes through the regular Get/Del/Stat
+	// (via GetFileStu/DelRequest/FileMetaRequest.VersionID) and listing every version through
+	// List (via ListRequest.WithVersions), rather than a parallel set of version-only methods.
+	RestoreVersion(ctx context.Context, req *RestoreVersionReq) error
+
+	// Copy and Rename are server-side operations within the same backend; see CopyRequest and
+	// RenameRequest. Copying or moving across backends goes through s3.ReplicateBucket instead,
+	// which only needs Get/Put and so works across any two File implementations.
+	Copy(ctx context.Context, req *CopyRequest) error
+	Rename(ctx context.Context, req *RenameRequest) error
+
+	// GetRange returns only part of an object; ParallelGet concurrently fetches an entire large
+	// object in opts.PartSize ranges, analogous to the s3manager Downloader. Both exist so a
+	// caller only needs part or all of an object without reading/discarding the rest of a plain
+	// Get stream, e.g. wkfs.File.Seek.
+	GetRange(ctx context.Context, req *RangeGetReq) (io.ReadCloser, error)
+	ParallelGet(ctx context.Context, fileName string, w io.WriterAt, metadata map[string]string, opts *ParallelGetOptions) error
+}
+
+// PutFileStu is the request to upload a file's contents.
+type PutFileStu struct {
+	FileName   string
+	DataStream io.Reader
+	Metadata   map[string]string
+}
+
+// GetFileStu is the request to download a file's contents. VersionID, if set, fetches that
+// specific, possibly non-current, version instead of the current object.
+type GetFileStu struct {
+	FileName  string
+	VersionID string
+	Metadata  map[string]string
+}
+
+// ListRequest lists files under a directory/prefix. If WithVersions is set, List returns one
+// entry per object version (including delete markers) instead of just the current object, for
+// buckets that have versioning enabled.
+type ListRequest struct {
+	DirectoryName string
+	Marker        string
+	PageSize      int32
+	WithVersions  bool
+	Metadata      map[string]string
+}
+
+// FilesInfo describes one file/object returned by List. VersionID, IsLatest and IsDeleteMarker
+// are only populated when the request set WithVersions; IsDeleteMarker distinguishes a delete
+// marker (the object was deleted, but earlier versions survive) from an actual object version.
+type FilesInfo struct {
+	FileName       string
+	Size           int64
+	LastModified   string
+	VersionID      string
+	IsLatest       bool
+	IsDeleteMarker bool
+}
+
+// ListResp is the paginated result of a ListRequest.
+type ListResp struct {
+	Files       []*FilesInfo
+	IsTruncated bool
+	Marker      string
+}
+
+// DelRequest deletes a file. VersionID, if set, permanently deletes that specific version
+// instead of creating a delete marker over the current object.
+type DelRequest struct {
+	FileName  string
+	VersionID string
+	Metadata  map[string]string
+}
+
+// FileMetaRequest fetches metadata for a file without downloading its contents. VersionID, if
+// set, fetches metadata for that specific, possibly non-current, version.
+type FileMetaRequest struct {
+	FileName  string
+	VersionID string
+	Metadata  map[string]string
+}
+
+// FileMetaResp is the result of a FileMetaRequest.
+type FileMetaResp struct {
+	Size         int64
+	LastModified string
+	Metadata     map[string][]string
+}
+
+// InitMultipartReq is the request to initiate a multipart upload.
+type InitMultipartReq struct {
+	FileName string
+	Metadata map[string]string
+}
+
+// UploadPartReq carries one part's payload for an in-progress multipart upload.
+type UploadPartReq struct {
+	FileName   string
+	UploadID   string
+	PartNumber int
+	DataStream io.Reader
+	Size       int64
+	Metadata   map[string]string
+}
+
+// PartInfo describes a part that has already been uploaded.
+type PartInfo struct {
+	PartNumber int
+	ETag       string
+	Size       int64
+}
+
+// CompleteMultipartReq completes a multipart upload, assembling the given parts in order.
+type CompleteMultipartReq struct {
+	FileName string
+	UploadID string
+	Parts    []*PartInfo
+	Metadata map[string]string
+}
+
+// AbortMultipartReq aborts an in-progress multipart upload and releases its parts.
+type AbortMultipartReq struct {
+	FileName string
+	UploadID string
+	Metadata map[string]string
+}
+
+// ListPartsReq lists the parts already uploaded for an in-progress multipart upload.
+type ListPartsReq struct {
+	FileName string
+	UploadID string
+	Metadata map[string]string
+}
+
+// PutLargeOptions configures the automatic chunking PutLarge does on top of the multipart primitives.
+type PutLargeOptions struct {
+	// PartSize is the size in bytes of each part. Defaults to s3.DefaultPartSize.
+	PartSize int64
+	// Concurrency is how many parts are uploaded in parallel. Defaults to s3.DefaultConcurrency.
+	Concurrency int
+	// MaxRetries is how many times a failed part is retried before the upload is aborted. Defaults to s3.DefaultMaxRetries.
+	MaxRetries int
+}
+
+// HTTPMethod is the HTTP verb a presigned URL is valid for.
+type HTTPMethod string
+
+const (
+	MethodGet    HTTPMethod = "GET"
+	MethodPut    HTTPMethod = "PUT"
+	MethodHead   HTTPMethod = "HEAD"
+	MethodDelete HTTPMethod = "DELETE"
+)
+
+// SignURLRequest describes a presigned URL to generate for a single object.
+type SignURLRequest struct {
+	FileName    string
+	Method      HTTPMethod
+	Expiry      time.Duration
+	ContentType string
+	ContentMD5  string
+	// ResponseHeaderOverrides maps query overrides such as response-content-disposition,
+	// response-content-type, etc. to the value the presigned GET should force in the response.
+	ResponseHeaderOverrides map[string]string
+	Metadata                map[string]string
+}
+
+// SignURLResponse is the generated presigned URL and the time it stops being valid.
+type SignURLResponse struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// PostPolicyCondition is one condition entry of a browser-upload POST policy document,
+// e.g. ["content-length-range", 0, 10485760] or ["eq", "$success_action_status", "201"].
+type PostPolicyCondition []interface{}
+
+// PostPolicyRequest describes a browser-direct-upload POST policy to sign.
+type PostPolicyRequest struct {
+	FileName            string
+	Expiry              time.Duration
+	ContentLengthRange  [2]int64
+	SuccessActionStatus string
+	Metadata            map[string]string
+}
+
+// PostPolicyResponse carries everything a frontend form needs to POST directly to the bucket.
+type PostPolicyResponse struct {
+	URL                string
+	AccessKeyFormField string
+	Policy             string
+	Signature          string
+	Fields             map[string]string
+}
+
+// RestoreVersionReq makes an old version the current object again via a server-side copy.
+type RestoreVersionReq struct {
+	FileName  string
+	VersionID string
+	Metadata  map[string]string
+}
+
+// CopyRequest server-side copies an object, optionally across buckets, within the same backend.
+// Source and destination file names are in the existing bucket/key format.
+type CopyRequest struct {
+	SourceFileName  string
+	DestFileName    string
+	MetadataReplace bool
+	StorageClass    string
+	ACL             string
+	Metadata        map[string]string
+}
+
+// RenameRequest moves an object from one name to another within the same backend.
+type RenameRequest struct {
+	SourceFileName string
+	DestFileName   string
+	Metadata       map[string]string
+}
+
+// RangeGetReq asks a backend to return only part of an object, e.g. for video seek, a parquet
+// footer read, or log tailing. Length of -1 means "to the end of the object".
+type RangeGetReq struct {
+	FileName string
+	Offset   int64
+	Length   int64
+	Metadata map[string]string
+}
+
+// ParallelGetOptions configures the concurrent ranged download ParallelGet performs.
+type ParallelGetOptions struct {
+	// PartSize is the size in bytes of each concurrently-fetched range. Defaults to s3.DefaultGetPartSize.
+	PartSize int64
+	// Concurrency is how many ranges are fetched in parallel. Defaults to s3.DefaultGetConcurrency.
+	Concurrency int
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alicloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const (
+	// CredentialsSourceStatic uses the static accessKeyID/accessKeySecret from the component config.
+	CredentialsSourceStatic = "static"
+	// CredentialsSourceEnv reads credentials from the OSS_ACCESS_KEY_ID/OSS_ACCESS_KEY_SECRET/
+	// OSS_SESSION_TOKEN environment variables.
+	CredentialsSourceEnv = "env"
+	// CredentialsSourceAliyunEcsRam fetches and auto-refreshes credentials from the ECS RAM role
+	// metadata endpoint.
+	CredentialsSourceAliyunEcsRam = "aliyun-ecs-ram"
+
+	ecsMetadataEndpoint = "http://100.100.100.200/latest/meta-data/ram/security-credentials/"
+	// refreshAhead is how long before expiry the provider tries to refresh; falling inside this
+	// window still serves the cached credentials, it just kicks off a refresh in the background.
+	refreshAhead = 5 * time.Minute
+	// ecsMetadataTimeout bounds a single metadata-endpoint request. The real ECS metadata service
+	// answers in milliseconds; an endpoint that is merely unreachable (the common case off-ECS)
+	// hangs on connect rather than erroring, so http.Get with no deadline can block Init forever.
+	ecsMetadataTimeout = 3 * time.Second
+)
+
+var ecsMetadataClient = &http.Client{Timeout: ecsMetadataTimeout}
+
+type ecsRAMSecurityCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	AccessKeySecret string    `json:"AccessKeySecret"`
+	SecurityToken   string    `json:"SecurityToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// staticCredentials is a fixed set of oss.Credentials; the SDK exposes Credentials only as an
+// interface with no exported constructor, so the ECS RAM response is wrapped in this local type.
+type staticCredentials struct {
+	accessKeyID     string
+	accessKeySecret string
+	securityToken   string
+}
+
+func (c staticCredentials) GetAccessKeyID() string     { return c.accessKeyID }
+func (c staticCredentials) GetAccessKeySecret() string { return c.accessKeySecret }
+func (c staticCredentials) GetSecurityToken() string   { return c.securityToken }
+
+// ecsRAMCredentialsProvider implements oss.CredentialsProvider on top of the ECS instance
+// metadata service. Credentials are cached and refreshed in the background; a failed refresh
+// never takes the component down, it just keeps serving the stale (possibly expired) value
+// until a refresh eventually succeeds.
+type ecsRAMCredentialsProvider struct {
+	endpoint string
+	role     string
+
+	mu    sync.RWMutex
+	creds oss.Credentials
+	exp   time.Time
+}
+
+// newEcsRAMCredentialsProvider fetches the initial credentials synchronously so a misconfigured
+// role or unreachable metadata endpoint fails Init fast, instead of silently succeeding with a
+// provider stuck on empty credentials. Only background refreshes (in refreshLoop) are allowed to
+// fail quietly and keep serving the last good value.
+func newEcsRAMCredentialsProvider(role string) (*ecsRAMCredentialsProvider, error) {
+	return newEcsRAMCredentialsProviderWithEndpoint(ecsMetadataEndpoint, role)
+}
+
+// newEcsRAMCredentialsProviderWithEndpoint is newEcsRAMCredentialsProvider with the metadata
+// endpoint as a parameter instead of the hardcoded ecsMetadataEndpoint, so tests can point it at
+// an httptest.Server instead of the real ECS metadata service.
+func newEcsRAMCredentialsProviderWithEndpoint(endpoint, role string) (*ecsRAMCredentialsProvider, error) {
+	p := &ecsRAMCredentialsProvider{endpoint: endpoint, role: role}
+	creds, exp, err := p.fetch()
+	if err != nil {
+		return nil, fmt.Errorf("fetch initial ecs ram credentials for role[%s] fail, err: %s", role, err.Error())
+	}
+	p.creds, p.exp = creds, exp
+	go p.refreshLoop()
+	return p, nil
+}
+
+// GetCredentials implements oss.CredentialsProvider.
+func (p *ecsRAMCredentialsProvider) GetCredentials() oss.Credentials {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.creds
+}
+
+func (p *ecsRAMCredentialsProvider) refreshLoop() {
+	for {
+		p.mu.RLock()
+		exp := p.exp
+		p.mu.RUnlock()
+
+		wait := time.Until(exp) - refreshAhead
+		if wait < time.Minute {
+			wait = time.Minute
+		}
+		time.Sleep(wait)
+		p.refresh()
+	}
+}
+
+func (p *ecsRAMCredentialsProvider) refresh() {
+	creds, exp, err := p.fetch()
+	if err != nil {
+		// keep serving whatever we had; the next tick will try again.
+		return
+	}
+	p.mu.Lock()
+	p.creds = creds
+	p.exp = exp
+	p.mu.Unlock()
+}
+
+func (p *ecsRAMCredentialsProvider) fetch() (oss.Credentials, time.Time, error) {
+	resp, err := ecsMetadataClient.Get(p.endpoint + p.role)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("ecs metadata endpoint returned status %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var sc ecsRAMSecurityCredentials
+	if err := json.Unmarshal(body, &sc); err != nil {
+		return nil, time.Time{}, err
+	}
+	creds := staticCredentials{accessKeyID: sc.AccessKeyID, accessKeySecret: sc.AccessKeySecret, securityToken: sc.SecurityToken}
+	return creds, sc.Expiration, nil
+}
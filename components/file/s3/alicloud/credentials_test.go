@@ -0,0 +1,105 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alicloud
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ecsMetadataStub(t *testing.T, sc ecsRAMSecurityCredentials) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(sc)
+	}))
+}
+
+func TestNewEcsRAMCredentialsProviderHappyPath(t *testing.T) {
+	exp := time.Now().Add(time.Hour).UTC()
+	server := ecsMetadataStub(t, ecsRAMSecurityCredentials{
+		AccessKeyID: "ak", AccessKeySecret: "sk", SecurityToken: "token", Expiration: exp,
+	})
+	defer server.Close()
+
+	p, err := newEcsRAMCredentialsProviderWithEndpoint(server.URL+"/", "some-role")
+	if err != nil {
+		t.Fatalf("newEcsRAMCredentialsProviderWithEndpoint failed: %v", err)
+	}
+	creds := p.GetCredentials()
+	if creds.GetAccessKeyID() != "ak" || creds.GetAccessKeySecret() != "sk" || creds.GetSecurityToken() != "token" {
+		t.Fatalf("GetCredentials() = %+v, want ak/sk/token", creds)
+	}
+	if !p.exp.Equal(exp) {
+		t.Fatalf("provider expiry = %v, want %v", p.exp, exp)
+	}
+}
+
+func TestNewEcsRAMCredentialsProviderFailsFastOnUnreachableEndpoint(t *testing.T) {
+	// A server that never responds stands in for a firewalled/unreachable metadata endpoint:
+	// the TCP connect succeeds but the request then hangs, which is exactly what
+	// ecsMetadataTimeout is there to bound.
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+	// deferred last so it runs first: server.Close waits for the blocked handler to return.
+	defer close(block)
+
+	start := time.Now()
+	_, err := newEcsRAMCredentialsProviderWithEndpoint(server.URL+"/", "some-role")
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected an error for an endpoint that never responds")
+	}
+	if elapsed > ecsMetadataTimeout+2*time.Second {
+		t.Fatalf("newEcsRAMCredentialsProviderWithEndpoint took %v to fail, want it bounded by ecsMetadataTimeout (%v)", elapsed, ecsMetadataTimeout)
+	}
+}
+
+func TestRefreshKeepsStaleCredentialsOnFailure(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	exp := time.Now().Add(time.Hour).UTC()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(ecsRAMSecurityCredentials{AccessKeyID: "ak", AccessKeySecret: "sk", Expiration: exp})
+	}))
+	defer server.Close()
+
+	p, err := newEcsRAMCredentialsProviderWithEndpoint(server.URL+"/", "some-role")
+	if err != nil {
+		t.Fatalf("newEcsRAMCredentialsProviderWithEndpoint failed: %v", err)
+	}
+	before := p.GetCredentials()
+
+	healthy.Store(false)
+	p.refresh()
+
+	after := p.GetCredentials()
+	if after.GetAccessKeySecret() != before.GetAccessKeySecret() {
+		t.Fatalf("a failed refresh must keep serving the stale credentials, got %+v after a failing refresh of %+v", after, before)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alicloud
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/layotto/components/file"
+)
+
+func TestBuildPostPolicyConditionsMinimal(t *testing.T) {
+	conditions := buildPostPolicyConditions("some/key", &file.PostPolicyRequest{Expiry: time.Minute})
+	if len(conditions) != 1 {
+		t.Fatalf("expected 1 condition with no optional constraints, got %d: %v", len(conditions), conditions)
+	}
+	if conditions[0][1] != "$key" || conditions[0][2] != "some/key" {
+		t.Fatalf("expected the $key condition to reference the object key, got %v", conditions[0])
+	}
+}
+
+func TestBuildPostPolicyConditionsWithConstraints(t *testing.T) {
+	req := &file.PostPolicyRequest{
+		Expiry:              time.Minute,
+		ContentLengthRange:  [2]int64{0, 1024},
+		SuccessActionStatus: "201",
+	}
+	conditions := buildPostPolicyConditions("some/key", req)
+	if len(conditions) != 3 {
+		t.Fatalf("expected 3 conditions, got %d: %v", len(conditions), conditions)
+	}
+	if conditions[1][0] != "content-length-range" || conditions[1][1] != int64(0) || conditions[1][2] != int64(1024) {
+		t.Fatalf("unexpected content-length-range condition: %v", conditions[1])
+	}
+	if conditions[2][1] != "$success_action_status" || conditions[2][2] != "201" {
+		t.Fatalf("unexpected success_action_status condition: %v", conditions[2])
+	}
+}
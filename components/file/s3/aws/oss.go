@@ -17,17 +17,28 @@
 package aws
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	aws_config "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"golang.org/x/time/rate"
 
 	"mosn.io/layotto/components/file"
 	loss "mosn.io/layotto/components/file/s3"
@@ -36,6 +47,20 @@ import (
 const (
 	endpointKey              = "endpoint"
 	defaultCredentialsSource = "provider"
+
+	// CredentialsSourceStatic uses the static accessKeyID/accessKeySecret from the component config.
+	CredentialsSourceStatic = "static"
+	// CredentialsSourceEnv reads credentials from AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/AWS_SESSION_TOKEN.
+	CredentialsSourceEnv = "env"
+	// CredentialsSourceEC2Role uses the SDK's default credential chain, which reaches the EC2
+	// instance metadata service; environment or shared-config credentials, when present, are
+	// used first.
+	CredentialsSourceEC2Role = "ec2-role"
+	// CredentialsSourceECSRole uses the SDK's default credential chain, which reaches the ECS task
+	// metadata endpoint; environment or shared-config credentials, when present, are used first.
+	CredentialsSourceECSRole = "ecs-role"
+	// CredentialsSourceAssumeRole assumes an IAM role via STS on top of the default credential chain.
+	CredentialsSourceAssumeRole = "assume-role"
 )
 
 var (
@@ -44,8 +69,9 @@ var (
 
 // AwsOss is a binding for aws oss storage.
 type AwsOss struct {
-	client map[string]*s3.Client
-	meta   map[string]*AwsOssMetaData
+	client  map[string]*s3.Client
+	meta    map[string]*AwsOssMetaData
+	limiter map[string]*rate.Limiter
 }
 
 // AwsOssMetaData describe a aws-oss instance.
@@ -54,12 +80,20 @@ type AwsOssMetaData struct {
 	EndPoint        string `json:"endpoint"` // eg. protocol://service-code.region-code.amazonaws.com
 	AccessKeyID     string `json:"accessKeyID"`
 	AccessKeySecret string `json:"accessKeySecret"`
+	// CredentialsSource selects how the client obtains credentials: static (default), env,
+	// ec2-role, ecs-role or assume-role. See the CredentialsSource* constants.
+	CredentialsSource string `json:"credentialsSource"`
+	// AssumeRoleARN is required when CredentialsSource is assume-role.
+	AssumeRoleARN string `json:"assumeRoleArn"`
+	// RateLimitBytesPerSecond caps download bandwidth for this endpoint; 0 means unlimited.
+	RateLimitBytesPerSecond int `json:"rateLimitBytesPerSecond"`
 }
 
 func NewAwsOss() file.File {
 	return &AwsOss{
-		client: make(map[string]*s3.Client),
-		meta:   make(map[string]*AwsOssMetaData),
+		client:  make(map[string]*s3.Client),
+		meta:    make(map[string]*AwsOssMetaData),
+		limiter: make(map[string]*rate.Limiter),
 	}
 }
 
@@ -80,29 +114,66 @@ func (a *AwsOss) Init(ctx context.Context, config *file.FileConfig) error {
 		}
 		a.client[data.EndPoint] = client
 		a.meta[data.EndPoint] = data
+		if data.RateLimitBytesPerSecond > 0 {
+			a.limiter[data.EndPoint] = rate.NewLimiter(rate.Limit(data.RateLimitBytesPerSecond), data.RateLimitBytesPerSecond)
+		}
 	}
 	return nil
 }
 
 // isAwsMetaValid check if the metadata valid.
 func (am *AwsOssMetaData) isAwsMetaValid() bool {
-	if am.AccessKeySecret == "" || am.EndPoint == "" || am.AccessKeyID == "" {
+	if am.EndPoint == "" {
 		return false
 	}
+	// only the static source needs a long-lived access key pair up front; the others derive
+	// credentials from the environment/instance at connect time.
+	if am.credentialsSource() == CredentialsSourceStatic {
+		return am.AccessKeySecret != "" && am.AccessKeyID != ""
+	}
 	return true
 }
 
+// credentialsSource returns the configured source, defaulting to static for backwards compatibility.
+func (am *AwsOssMetaData) credentialsSource() string {
+	if am.CredentialsSource == "" {
+		return CredentialsSourceStatic
+	}
+	return am.CredentialsSource
+}
+
 // createOssClient by input meta info.
 func (a *AwsOss) createOssClient(meta *AwsOssMetaData) (*s3.Client, error) {
 	optFunc := []func(options *aws_config.LoadOptions) error{
 		aws_config.WithRegion(meta.Region),
-		aws_config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
+	}
+
+	switch meta.credentialsSource() {
+	case CredentialsSourceStatic:
+		optFunc = append(optFunc, aws_config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
 			Value: aws.Credentials{
 				AccessKeyID: meta.AccessKeyID, SecretAccessKey: meta.AccessKeySecret,
 				Source: defaultCredentialsSource,
 			},
-		}),
+		}))
+	case CredentialsSourceEnv, CredentialsSourceEC2Role, CredentialsSourceECSRole:
+		// env vars, EC2 instance-role and ECS task-role credentials are all served by
+		// LoadDefaultConfig's default chain; no explicit provider option is needed.
+	case CredentialsSourceAssumeRole:
+		if meta.AssumeRoleARN == "" {
+			return nil, errors.New("assumeRoleArn is required when credentialsSource is assume-role")
+		}
+		baseCfg, err := aws_config.LoadDefaultConfig(context.TODO(), aws_config.WithRegion(meta.Region))
+		if err != nil {
+			return nil, err
+		}
+		stsClient := sts.NewFromConfig(baseCfg)
+		optFunc = append(optFunc, aws_config.WithCredentialsProvider(
+			aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, meta.AssumeRoleARN))))
+	default:
+		return nil, fmt.Errorf("unsupported credentialsSource: %s", meta.CredentialsSource)
 	}
+
 	cfg, err := aws_config.LoadDefaultConfig(context.TODO(), optFunc...)
 	if err != nil {
 		return nil, err
@@ -153,6 +224,20 @@ func (a *AwsOss) selectClient(meta map[string]string) (*s3.Client, error) {
 	return nil, ErrNotSpecifyEndpoint
 }
 
+// resolveEndpoint returns the endpoint key that selectClient would use for meta, so callers can
+// look up the matching per-endpoint rate limiter.
+func (a *AwsOss) resolveEndpoint(meta map[string]string) string {
+	if ep, ok := meta[endpointKey]; ok {
+		if _, ok := a.client[ep]; ok {
+			return ep
+		}
+	}
+	for ep := range a.client {
+		return ep
+	}
+	return ""
+}
+
 // Get object from aws oss.
 func (a *AwsOss) Get(ctx context.Context, st *file.GetFileStu) (io.ReadCloser, error) {
 	bucket, err := loss.GetBucketName(st.FileName)
@@ -167,6 +252,9 @@ func (a *AwsOss) Get(ctx context.Context, st *file.GetFileStu) (io.ReadCloser, e
 		Bucket: &bucket,
 		Key:    &key,
 	}
+	if st.VersionID != "" {
+		input.VersionId = &st.VersionID
+	}
 	client, err := a.selectClient(st.Metadata)
 	if err != nil {
 		return nil, err
@@ -175,7 +263,43 @@ func (a *AwsOss) Get(ctx context.Context, st *file.GetFileStu) (io.ReadCloser, e
 	if err != nil {
 		return nil, err
 	}
-	return ob.Body, nil
+	return loss.NewRateLimitedReadCloser(ctx, ob.Body, a.limiter[a.resolveEndpoint(st.Metadata)]), nil
+}
+
+// GetRange returns only req.Offset..req.Offset+req.Length of the object (req.Length < 0 means
+// to the end), for callers that only need a byte range - video seek, a parquet footer read, log
+// tailing - instead of the whole object.
+func (a *AwsOss) GetRange(ctx context.Context, req *file.RangeGetReq) (io.ReadCloser, error) {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss get range[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss get range[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	rangeHeader := loss.RangeHeader(req.Offset, req.Length)
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key, Range: &rangeHeader})
+	if err != nil {
+		return nil, fmt.Errorf("awsoss get range[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	return loss.NewRateLimitedReadCloser(ctx, out.Body, a.limiter[a.resolveEndpoint(req.Metadata)]), nil
+}
+
+// ParallelGet concurrently fetches N byte-ranges of fileName and writes them into w, analogous
+// to the s3manager Downloader, using the size reported by Stat to plan the ranges.
+func (a *AwsOss) ParallelGet(ctx context.Context, fileName string, w io.WriterAt, metadata map[string]string, opts *file.ParallelGetOptions) error {
+	meta, err := a.Stat(ctx, &file.FileMetaRequest{FileName: fileName, Metadata: metadata})
+	if err != nil {
+		return fmt.Errorf("awsoss parallel get[%s] fail to stat,err: %s", fileName, err.Error())
+	}
+	return loss.ParallelGet(ctx, meta.Size, w, opts, func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		return a.GetRange(ctx, &file.RangeGetReq{FileName: fileName, Offset: offset, Length: length, Metadata: metadata})
+	})
 }
 
 // List objects from aws oss.
@@ -185,16 +309,20 @@ func (a *AwsOss) List(ctx context.Context, st *file.ListRequest) (*file.ListResp
 		return nil, fmt.Errorf("list bucket[%s] fail, err: %s", st.DirectoryName, err.Error())
 	}
 	prefix := loss.GetFilePrefixName(st.DirectoryName)
+	client, err := a.selectClient(st.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("list bucket[%s] fail, err: %s", st.DirectoryName, err.Error())
+	}
+	if st.WithVersions {
+		return a.listVersions(ctx, client, bucket, st, prefix)
+	}
+
 	input := &s3.ListObjectsInput{
 		Bucket:  &bucket,
 		MaxKeys: st.PageSize,
 		Marker:  &st.Marker,
 		Prefix:  &prefix,
 	}
-	client, err := a.selectClient(st.Metadata)
-	if err != nil {
-		return nil, fmt.Errorf("list bucket[%s] fail, err: %s", st.DirectoryName, err.Error())
-	}
 	out, err := client.ListObjects(context.TODO(), input)
 	if err != nil {
 		return nil, fmt.Errorf("list bucket[%s] fail, err: %s", st.DirectoryName, err.Error())
@@ -214,6 +342,48 @@ func (a *AwsOss) List(ctx context.Context, st *file.ListRequest) (*file.ListResp
 	return resp, nil
 }
 
+// listVersions lists every version (and delete marker) under prefix, for buckets that have
+// versioning enabled. The key marker is reused as st.Marker/resp.Marker so List's regular
+// pagination loop (see ReplicateBucket) keeps working unchanged; the version ID marker needed to
+// paginate within a key's versions is folded into resp.Marker via loss.JoinVersionMarker.
+func (a *AwsOss) listVersions(ctx context.Context, client *s3.Client, bucket string, st *file.ListRequest, prefix string) (*file.ListResp, error) {
+	keyMarker, versionIDMarker := loss.SplitVersionMarker(st.Marker)
+	out, err := client.ListObjectVersions(ctx, &s3.ListObjectVersionsInput{
+		Bucket:          &bucket,
+		Prefix:          &prefix,
+		KeyMarker:       &keyMarker,
+		VersionIdMarker: &versionIDMarker,
+		MaxKeys:         st.PageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list bucket[%s] fail, err: %s", st.DirectoryName, err.Error())
+	}
+
+	resp := &file.ListResp{IsTruncated: out.IsTruncated}
+	if out.IsTruncated {
+		nextKeyMarker, nextVersionIDMarker := "", ""
+		if out.NextKeyMarker != nil {
+			nextKeyMarker = *out.NextKeyMarker
+		}
+		if out.NextVersionIdMarker != nil {
+			nextVersionIDMarker = *out.NextVersionIdMarker
+		}
+		resp.Marker = loss.JoinVersionMarker(nextKeyMarker, nextVersionIDMarker)
+	}
+	for _, v := range out.DeleteMarkers {
+		resp.Files = append(resp.Files, &file.FilesInfo{
+			FileName: *v.Key, VersionID: *v.VersionId, IsLatest: v.IsLatest, IsDeleteMarker: true,
+		})
+	}
+	for _, v := range out.Versions {
+		resp.Files = append(resp.Files, &file.FilesInfo{
+			FileName: *v.Key, VersionID: *v.VersionId, IsLatest: v.IsLatest,
+			Size: v.Size, LastModified: v.LastModified.String(),
+		})
+	}
+	return resp, nil
+}
+
 // Del object in aws oss.
 func (a *AwsOss) Del(ctx context.Context, st *file.DelRequest) error {
 	bucket, err := loss.GetBucketName(st.FileName)
@@ -228,6 +398,9 @@ func (a *AwsOss) Del(ctx context.Context, st *file.DelRequest) error {
 		Bucket: &bucket,
 		Key:    &key,
 	}
+	if st.VersionID != "" {
+		input.VersionId = &st.VersionID
+	}
 	client, err := a.selectClient(st.Metadata)
 	if err != nil {
 		return err
@@ -238,6 +411,385 @@ func (a *AwsOss) Del(ctx context.Context, st *file.DelRequest) error {
 	}
 	return nil
 }
+// InitMultipart initiates a multipart upload and returns the uploadID other multipart calls reference.
+func (a *AwsOss) InitMultipart(ctx context.Context, req *file.InitMultipartReq) (string, error) {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return "", fmt.Errorf("awsoss init multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return "", fmt.Errorf("awsoss init multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return "", err
+	}
+	out, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: &bucket, Key: &key})
+	if err != nil {
+		return "", fmt.Errorf("awsoss init multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	return *out.UploadId, nil
+}
+
+// UploadPart uploads a single part of a previously-initiated multipart upload.
+func (a *AwsOss) UploadPart(ctx context.Context, req *file.UploadPartReq) (*file.PartInfo, error) {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss upload part[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss upload part[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	partNumber := int32(req.PartNumber)
+	out, err := client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     &bucket,
+		Key:        &key,
+		UploadId:   &req.UploadID,
+		PartNumber: partNumber,
+		Body:       req.DataStream,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("awsoss upload part[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	return &file.PartInfo{PartNumber: req.PartNumber, ETag: *out.ETag, Size: req.Size}, nil
+}
+
+// CompleteMultipart assembles the uploaded parts into the final object.
+func (a *AwsOss) CompleteMultipart(ctx context.Context, req *file.CompleteMultipartReq) error {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return fmt.Errorf("awsoss complete multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return fmt.Errorf("awsoss complete multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return err
+	}
+	parts := make([]types.CompletedPart, 0, len(req.Parts))
+	for _, p := range req.Parts {
+		partNumber := int32(p.PartNumber)
+		parts = append(parts, types.CompletedPart{PartNumber: partNumber, ETag: aws.String(p.ETag)})
+	}
+	_, err = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &bucket,
+		Key:             &key,
+		UploadId:        &req.UploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return fmt.Errorf("awsoss complete multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	return nil
+}
+
+// AbortMultipart cancels an in-progress multipart upload and releases its uploaded parts.
+func (a *AwsOss) AbortMultipart(ctx context.Context, req *file.AbortMultipartReq) error {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return fmt.Errorf("awsoss abort multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return fmt.Errorf("awsoss abort multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{Bucket: &bucket, Key: &key, UploadId: &req.UploadID})
+	if err != nil {
+		return fmt.Errorf("awsoss abort multipart upload[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	return nil
+}
+
+// ListParts lists the parts already uploaded for an in-progress multipart upload.
+func (a *AwsOss) ListParts(ctx context.Context, req *file.ListPartsReq) ([]*file.PartInfo, error) {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss list parts[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss list parts[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.ListParts(ctx, &s3.ListPartsInput{Bucket: &bucket, Key: &key, UploadId: &req.UploadID})
+	if err != nil {
+		return nil, fmt.Errorf("awsoss list parts[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	parts := make([]*file.PartInfo, 0, len(out.Parts))
+	for _, p := range out.Parts {
+		parts = append(parts, &file.PartInfo{PartNumber: int(p.PartNumber), ETag: *p.ETag, Size: p.Size})
+	}
+	return parts, nil
+}
+
+// PutLarge automatically splits st.DataStream into parts, uploads them concurrently with retries,
+// and checkpoints progress locally so an interrupted upload can be resumed.
+func (a *AwsOss) PutLarge(ctx context.Context, st *file.PutFileStu, opts *file.PutLargeOptions) error {
+	return loss.PutLarge(st.FileName, st.DataStream, opts,
+		func() (string, error) {
+			return a.InitMultipart(ctx, &file.InitMultipartReq{FileName: st.FileName, Metadata: st.Metadata})
+		},
+		func(uploadID string, partNumber int, body []byte) (*file.PartInfo, error) {
+			return a.UploadPart(ctx, &file.UploadPartReq{
+				FileName: st.FileName, UploadID: uploadID, PartNumber: partNumber,
+				DataStream: bytes.NewReader(body), Size: int64(len(body)), Metadata: st.Metadata,
+			})
+		},
+		func(uploadID string, parts []*file.PartInfo) error {
+			return a.CompleteMultipart(ctx, &file.CompleteMultipartReq{FileName: st.FileName, UploadID: uploadID, Parts: parts, Metadata: st.Metadata})
+		},
+		func(uploadID string) error {
+			return a.AbortMultipart(ctx, &file.AbortMultipartReq{FileName: st.FileName, UploadID: uploadID, Metadata: st.Metadata})
+		},
+	)
+}
+
+// Copy server-side copies an object, optionally across buckets, without reading the bytes
+// through Layotto.
+func (a *AwsOss) Copy(ctx context.Context, req *file.CopyRequest) error {
+	dstBucket, err := loss.GetBucketName(req.DestFileName)
+	if err != nil {
+		return fmt.Errorf("awsoss copy[%s -> %s] fail,err: %s", req.SourceFileName, req.DestFileName, err.Error())
+	}
+	dstKey, err := loss.GetFileName(req.DestFileName)
+	if err != nil {
+		return fmt.Errorf("awsoss copy[%s -> %s] fail,err: %s", req.SourceFileName, req.DestFileName, err.Error())
+	}
+	srcBucket, err := loss.GetBucketName(req.SourceFileName)
+	if err != nil {
+		return fmt.Errorf("awsoss copy[%s -> %s] fail,err: %s", req.SourceFileName, req.DestFileName, err.Error())
+	}
+	srcKey, err := loss.GetFileName(req.SourceFileName)
+	if err != nil {
+		return fmt.Errorf("awsoss copy[%s -> %s] fail,err: %s", req.SourceFileName, req.DestFileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return err
+	}
+	copySource := url.QueryEscape(srcBucket + "/" + srcKey)
+	input := &s3.CopyObjectInput{Bucket: &dstBucket, Key: &dstKey, CopySource: &copySource}
+	if req.MetadataReplace {
+		input.MetadataDirective = types.MetadataDirectiveReplace
+	}
+	if req.StorageClass != "" {
+		input.StorageClass = types.StorageClass(req.StorageClass)
+	}
+	if req.ACL != "" {
+		input.ACL = types.ObjectCannedACL(req.ACL)
+	}
+	_, err = client.CopyObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("awsoss copy[%s -> %s] fail,err: %s", req.SourceFileName, req.DestFileName, err.Error())
+	}
+	return nil
+}
+
+// Rename moves an object from one name to another. It is Copy+Del under the hood but exposed
+// as one call, and is idempotent on retry: if the source is already gone, that's treated as a
+// previous attempt having already completed the rename rather than an error.
+func (a *AwsOss) Rename(ctx context.Context, req *file.RenameRequest) error {
+	err := a.Copy(ctx, &file.CopyRequest{SourceFileName: req.SourceFileName, DestFileName: req.DestFileName, Metadata: req.Metadata})
+	if err != nil {
+		if _, statErr := a.Stat(ctx, &file.FileMetaRequest{FileName: req.SourceFileName, Metadata: req.Metadata}); statErr == file.ErrNotExist {
+			// source is already gone: a previous attempt likely already renamed it.
+			return nil
+		}
+		return fmt.Errorf("awsoss rename[%s -> %s] fail,err: %s", req.SourceFileName, req.DestFileName, err.Error())
+	}
+	if err := a.Del(ctx, &file.DelRequest{FileName: req.SourceFileName, Metadata: req.Metadata}); err != nil {
+		return fmt.Errorf("awsoss rename[%s -> %s] fail to delete source,err: %s", req.SourceFileName, req.DestFileName, err.Error())
+	}
+	return nil
+}
+
+// RestoreVersion makes an old version the current object again by server-side-copying it
+// over the current key.
+func (a *AwsOss) RestoreVersion(ctx context.Context, req *file.RestoreVersionReq) error {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return fmt.Errorf("awsoss restore version[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return fmt.Errorf("awsoss restore version[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return err
+	}
+	copySource := url.QueryEscape(bucket+"/"+key) + "?versionId=" + url.QueryEscape(req.VersionID)
+	_, err = client.CopyObject(ctx, &s3.CopyObjectInput{Bucket: &bucket, Key: &key, CopySource: &copySource})
+	if err != nil {
+		return fmt.Errorf("awsoss restore version[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	return nil
+}
+
+// SignURL generates a presigned URL so callers can GET/PUT/HEAD/DELETE an object without
+// proxying bytes through Layotto.
+func (a *AwsOss) SignURL(ctx context.Context, req *file.SignURLRequest) (*file.SignURLResponse, error) {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss sign url[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss sign url[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	presignClient := s3.NewPresignClient(client, func(po *s3.PresignOptions) {
+		po.Expires = req.Expiry
+	})
+
+	var (
+		presigned *v4.PresignedHTTPRequest
+		signErr   error
+	)
+	switch req.Method {
+	case file.MethodGet:
+		input := &s3.GetObjectInput{Bucket: &bucket, Key: &key}
+		for header, value := range req.ResponseHeaderOverrides {
+			applyResponseHeaderOverride(input, header, value)
+		}
+		presigned, signErr = presignClient.PresignGetObject(ctx, input)
+	case file.MethodPut:
+		input := &s3.PutObjectInput{Bucket: &bucket, Key: &key}
+		if req.ContentType != "" {
+			input.ContentType = &req.ContentType
+		}
+		if req.ContentMD5 != "" {
+			input.ContentMD5 = &req.ContentMD5
+		}
+		presigned, signErr = presignClient.PresignPutObject(ctx, input)
+	case file.MethodHead:
+		presigned, signErr = presignClient.PresignHeadObject(ctx, &s3.HeadObjectInput{Bucket: &bucket, Key: &key})
+	case file.MethodDelete:
+		presigned, signErr = presignClient.PresignDeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &bucket, Key: &key})
+	default:
+		return nil, fmt.Errorf("awsoss sign url[%s] fail, err: unsupported method %s", req.FileName, req.Method)
+	}
+	if signErr != nil {
+		return nil, fmt.Errorf("awsoss sign url[%s] fail,err: %s", req.FileName, signErr.Error())
+	}
+	return &file.SignURLResponse{URL: presigned.URL, ExpiresAt: time.Now().Add(req.Expiry)}, nil
+}
+
+// applyResponseHeaderOverride sets the response-content-* override fields GetObjectInput supports.
+func applyResponseHeaderOverride(input *s3.GetObjectInput, header, value string) {
+	switch header {
+	case "response-content-disposition":
+		input.ResponseContentDisposition = aws.String(value)
+	case "response-content-type":
+		input.ResponseContentType = aws.String(value)
+	case "response-content-encoding":
+		input.ResponseContentEncoding = aws.String(value)
+	case "response-cache-control":
+		input.ResponseCacheControl = aws.String(value)
+	}
+}
+
+// SignPostPolicy builds a POST policy document that lets a browser upload directly to the
+// bucket, the same shape as AliCloudOSS.SignPostPolicy, signed with AWS SigV4 POST signing.
+func (a *AwsOss) SignPostPolicy(ctx context.Context, req *file.PostPolicyRequest) (*file.PostPolicyResponse, error) {
+	bucket, err := loss.GetBucketName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss sign post policy[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	key, err := loss.GetFileName(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss sign post policy[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	meta, ok := a.meta[req.Metadata[endpointKey]]
+	if !ok {
+		if len(a.meta) != 1 {
+			return nil, ErrNotSpecifyEndpoint
+		}
+		for _, m := range a.meta {
+			meta = m
+		}
+	}
+	client, err := a.selectClient(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	// meta.AccessKeySecret is only populated when CredentialsSource is static; for
+	// env/ec2-role/ecs-role/assume-role it's empty, so read credentials back off the client's
+	// configured provider instead - reading the raw metadata struct would silently sign with an
+	// empty secret.
+	creds, err := client.Options().Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss sign post policy[%s] fail to resolve credentials, err: %s", req.FileName, err.Error())
+	}
+	if creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("awsoss sign post policy[%s] fail, err: no credentials available to sign with", req.FileName)
+	}
+
+	conditions := []file.PostPolicyCondition{
+		{"eq", "$key", key},
+		{"eq", "$bucket", bucket},
+	}
+	if req.ContentLengthRange[1] > 0 {
+		conditions = append(conditions, file.PostPolicyCondition{"content-length-range", req.ContentLengthRange[0], req.ContentLengthRange[1]})
+	}
+	if req.SuccessActionStatus != "" {
+		conditions = append(conditions, file.PostPolicyCondition{"eq", "$success_action_status", req.SuccessActionStatus})
+	}
+	if creds.SessionToken != "" {
+		conditions = append(conditions, file.PostPolicyCondition{"eq", "$x-amz-security-token", creds.SessionToken})
+	}
+
+	policy := map[string]interface{}{
+		"expiration": time.Now().Add(req.Expiry).UTC().Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	}
+	policyJSON, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("awsoss sign post policy[%s] fail,err: %s", req.FileName, err.Error())
+	}
+	encodedPolicy := base64.StdEncoding.EncodeToString(policyJSON)
+
+	h := hmac.New(sha256.New, []byte(creds.SecretAccessKey))
+	h.Write([]byte(encodedPolicy))
+	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	fields := map[string]string{
+		"key":            key,
+		"AWSAccessKeyId": creds.AccessKeyID,
+		"policy":         encodedPolicy,
+		"signature":      signature,
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+
+	return &file.PostPolicyResponse{
+		URL:                fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, meta.Region),
+		AccessKeyFormField: "AWSAccessKeyId",
+		Policy:             encodedPolicy,
+		Signature:          signature,
+		Fields:             fields,
+	}, nil
+}
+
 func (a *AwsOss) Stat(ctx context.Context, st *file.FileMetaRequest) (*file.FileMetaResp, error) {
 	bucket, err := loss.GetBucketName(st.FileName)
 	if err != nil {
@@ -251,6 +803,9 @@ func (a *AwsOss) Stat(ctx context.Context, st *file.FileMetaRequest) (*file.File
 		Bucket: &bucket,
 		Key:    &key,
 	}
+	if st.VersionID != "" {
+		input.VersionId = &st.VersionID
+	}
 	client, err := a.selectClient(st.Metadata)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,263 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package s3 holds logic shared by the AliCloud and AWS backends: the resumable multipart
+// upload algorithm, the ranged-download algorithm and the local checkpoint format they use, all
+// expressed in terms of the request/response types declared on file.File in components/file.
+package s3
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"mosn.io/layotto/components/file"
+)
+
+const (
+	// DefaultPartSize is the part size PutLarge uses when the caller doesn't set one.
+	DefaultPartSize = 8 * 1024 * 1024
+	// DefaultConcurrency is the number of parts PutLarge uploads at once by default.
+	DefaultConcurrency = 4
+	// DefaultMaxRetries is how many times PutLarge retries a failed part before giving up.
+	DefaultMaxRetries = 3
+)
+
+func withPutLargeDefaults(o *file.PutLargeOptions) *file.PutLargeOptions {
+	opts := file.PutLargeOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.PartSize <= 0 {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	return &opts
+}
+
+// Checkpoint is the local resume state for an in-flight PutLarge upload, keyed by file name.
+// It lives in this shared package so both the AliCloud and AWS backends can read/write the same format.
+type Checkpoint struct {
+	FileName string           `json:"fileName"`
+	UploadID string           `json:"uploadID"`
+	Parts    []*file.PartInfo `json:"parts"`
+}
+
+// checkpointPath returns a per-file checkpoint location under the OS temp dir.
+func checkpointPath(fileName string) string {
+	return filepath.Join(os.TempDir(), "layotto-oss-checkpoint-"+url.QueryEscape(fileName)+".json")
+}
+
+// LoadCheckpoint reads a previously saved checkpoint for fileName, if any.
+// A missing checkpoint is not an error: it just means there's nothing to resume.
+func LoadCheckpoint(fileName string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(checkpointPath(fileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Save persists the checkpoint so an interrupted upload can be resumed later.
+func (c *Checkpoint) Save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(checkpointPath(c.FileName), data, 0600)
+}
+
+// RemoveCheckpoint deletes the checkpoint for fileName once its upload has completed or been aborted.
+func RemoveCheckpoint(fileName string) error {
+	err := os.Remove(checkpointPath(fileName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// chunk is one slice of the source stream read into memory ahead of uploading.
+type chunk struct {
+	number int
+	data   []byte
+}
+
+// chunkOrErr is what the producer goroutine in PutLarge sends: either the next chunk, or the
+// error that made it stop reading early. A non-nil err must always be the last value received on
+// the channel before it closes.
+type chunkOrErr struct {
+	chunk chunk
+	err   error
+}
+
+// PutLarge drives the generic "split, upload concurrently, retry, checkpoint" algorithm shared by
+// every backend's high-level multipart convenience method. Backends supply the actual SDK calls.
+func PutLarge(fileName string, data io.Reader, opts *file.PutLargeOptions,
+	initFn func() (uploadID string, err error),
+	uploadFn func(uploadID string, partNumber int, body []byte) (*file.PartInfo, error),
+	completeFn func(uploadID string, parts []*file.PartInfo) error,
+	abortFn func(uploadID string) error) error {
+
+	opts = withPutLargeDefaults(opts)
+
+	cp, err := LoadCheckpoint(fileName)
+	if err != nil {
+		return fmt.Errorf("put large file[%s] fail, err reading checkpoint: %s", fileName, err.Error())
+	}
+
+	var uploadID string
+	done := make(map[int]*file.PartInfo)
+	if cp != nil && cp.UploadID != "" {
+		uploadID = cp.UploadID
+		for _, p := range cp.Parts {
+			done[p.PartNumber] = p
+		}
+	} else {
+		uploadID, err = initFn()
+		if err != nil {
+			return fmt.Errorf("put large file[%s] fail, err: %s", fileName, err.Error())
+		}
+	}
+
+	// cancel tells the producer goroutine to stop reading early if the consumer below gives up
+	// first (e.g. a part permanently failed); closing it is safe even after the producer has
+	// already finished on its own, since nothing reads from it after that point.
+	cancel := make(chan struct{})
+	defer close(cancel)
+
+	chunks := make(chan chunkOrErr)
+	go func() {
+		defer close(chunks)
+		number := 1
+		for {
+			buf := make([]byte, opts.PartSize)
+			n, readErr := io.ReadFull(data, buf)
+			if n > 0 {
+				select {
+				case chunks <- chunkOrErr{chunk: chunk{number: number, data: buf[:n]}}:
+					number++
+				case <-cancel:
+					return
+				}
+			}
+			if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+				return
+			}
+			if readErr != nil {
+				select {
+				case chunks <- chunkOrErr{err: readErr}:
+				case <-cancel:
+				}
+				return
+			}
+		}
+	}()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, opts.Concurrency)
+	for c := range chunks {
+		if c.err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("reading source stream: %s", c.err.Error())
+			}
+			mu.Unlock()
+			break
+		}
+
+		mu.Lock()
+		err := firstErr
+		mu.Unlock()
+		if err != nil {
+			break
+		}
+		mu.Lock()
+		existing, ok := done[c.chunk.number]
+		mu.Unlock()
+		if ok && existing.Size == int64(len(c.chunk.data)) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(c chunk) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var part *file.PartInfo
+			var uploadErr error
+			for attempt := 0; attempt <= opts.MaxRetries; attempt++ {
+				part, uploadErr = uploadFn(uploadID, c.number, c.data)
+				if uploadErr == nil {
+					break
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if uploadErr != nil {
+				if firstErr == nil {
+					firstErr = uploadErr
+				}
+				return
+			}
+			done[c.number] = part
+			_ = (&Checkpoint{FileName: fileName, UploadID: uploadID, Parts: sortedParts(done)}).Save()
+		}(c.chunk)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		if abortFn != nil {
+			_ = abortFn(uploadID)
+			_ = RemoveCheckpoint(fileName)
+		}
+		return fmt.Errorf("put large file[%s] fail, err: %s", fileName, firstErr.Error())
+	}
+
+	if err := completeFn(uploadID, sortedParts(done)); err != nil {
+		return fmt.Errorf("put large file[%s] fail to complete, err: %s", fileName, err.Error())
+	}
+	return RemoveCheckpoint(fileName)
+}
+
+func sortedParts(done map[int]*file.PartInfo) []*file.PartInfo {
+	parts := make([]*file.PartInfo, 0, len(done))
+	for _, p := range done {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"mosn.io/layotto/components/file"
+)
+
+func TestSortedParts(t *testing.T) {
+	done := map[int]*file.PartInfo{
+		3: {PartNumber: 3, ETag: "c"},
+		1: {PartNumber: 1, ETag: "a"},
+		2: {PartNumber: 2, ETag: "b"},
+	}
+	parts := sortedParts(done)
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+	for i, p := range parts {
+		if p.PartNumber != i+1 {
+			t.Fatalf("expected parts in order, got part %d at index %d", p.PartNumber, i)
+		}
+	}
+}
+
+func TestPutLargeUploadsAllChunksInOrder(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 25)
+	opts := &file.PutLargeOptions{PartSize: 10, Concurrency: 2, MaxRetries: 0}
+
+	var mu sync.Mutex
+	uploaded := make(map[int]int) // partNumber -> size
+
+	err := PutLarge("test-put-large-ok", bytes.NewReader(data), opts,
+		func() (string, error) { return "upload-1", nil },
+		func(uploadID string, partNumber int, body []byte) (*file.PartInfo, error) {
+			mu.Lock()
+			uploaded[partNumber] = len(body)
+			mu.Unlock()
+			return &file.PartInfo{PartNumber: partNumber, Size: int64(len(body))}, nil
+		},
+		func(uploadID string, parts []*file.PartInfo) error {
+			if len(parts) != 3 {
+				return fmt.Errorf("expected 3 parts, got %d", len(parts))
+			}
+			return nil
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("PutLarge failed: %v", err)
+	}
+	if uploaded[1] != 10 || uploaded[2] != 10 || uploaded[3] != 5 {
+		t.Fatalf("unexpected part sizes: %v", uploaded)
+	}
+	_ = RemoveCheckpoint("test-put-large-ok")
+}
+
+func TestPutLargeRetriesFailedPartThenSucceeds(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 10)
+	opts := &file.PutLargeOptions{PartSize: 10, Concurrency: 1, MaxRetries: 2}
+
+	var attempts int
+	err := PutLarge("test-put-large-retry", bytes.NewReader(data), opts,
+		func() (string, error) { return "upload-1", nil },
+		func(uploadID string, partNumber int, body []byte) (*file.PartInfo, error) {
+			attempts++
+			if attempts < 2 {
+				return nil, errors.New("transient upload error")
+			}
+			return &file.PartInfo{PartNumber: partNumber, Size: int64(len(body))}, nil
+		},
+		func(uploadID string, parts []*file.PartInfo) error { return nil },
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("PutLarge failed: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected exactly 2 attempts, got %d", attempts)
+	}
+	_ = RemoveCheckpoint("test-put-large-retry")
+}
+
+// failingReader returns one chunk of data then a permanent read error, simulating a broken
+// source stream. PutLarge must abort instead of completing with whatever it managed to read.
+type failingReader struct {
+	data []byte
+	read bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if !r.read {
+		r.read = true
+		n := copy(p, r.data)
+		return n, nil
+	}
+	return 0, errors.New("simulated read error")
+}
+
+func TestPutLargeAbortsOnSourceReadError(t *testing.T) {
+	opts := &file.PutLargeOptions{PartSize: 10, Concurrency: 1, MaxRetries: 0}
+	var aborted bool
+
+	err := PutLarge("test-put-large-read-err", &failingReader{data: bytes.Repeat([]byte("a"), 10)}, opts,
+		func() (string, error) { return "upload-1", nil },
+		func(uploadID string, partNumber int, body []byte) (*file.PartInfo, error) {
+			return &file.PartInfo{PartNumber: partNumber, Size: int64(len(body))}, nil
+		},
+		func(uploadID string, parts []*file.PartInfo) error {
+			return fmt.Errorf("completeFn must not be called when the source stream failed")
+		},
+		func(uploadID string) error {
+			aborted = true
+			return nil
+		},
+	)
+	if err == nil {
+		t.Fatal("expected PutLarge to fail on a source read error, got nil")
+	}
+	if !aborted {
+		t.Fatal("expected abortFn to be called when the source stream failed")
+	}
+	_ = RemoveCheckpoint("test-put-large-read-err")
+}
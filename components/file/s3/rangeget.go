@@ -0,0 +1,164 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/time/rate"
+
+	"mosn.io/layotto/components/file"
+)
+
+const (
+	// DefaultGetPartSize is the byte-range size ParallelGet fetches per request when unset.
+	DefaultGetPartSize = 8 * 1024 * 1024
+	// DefaultGetConcurrency is how many ranges ParallelGet fetches at once when unset.
+	DefaultGetConcurrency = 4
+)
+
+// RangeHeader renders an offset/length pair as an HTTP Range header value (bytes=start-end).
+// Length < 0 means open-ended ("bytes=start-").
+func RangeHeader(offset, length int64) string {
+	if length < 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
+
+func withParallelGetDefaults(o *file.ParallelGetOptions) *file.ParallelGetOptions {
+	opts := file.ParallelGetOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.PartSize <= 0 {
+		opts.PartSize = DefaultGetPartSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultGetConcurrency
+	}
+	return &opts
+}
+
+// ParallelGet concurrently fetches size bytes of a large object in opts.PartSize ranges and
+// writes each into w at its offset, analogous to the s3manager Downloader.
+func ParallelGet(ctx context.Context, size int64, w io.WriterAt, opts *file.ParallelGetOptions,
+	getRangeFn func(ctx context.Context, offset, length int64) (io.ReadCloser, error)) error {
+
+	opts = withParallelGetDefaults(opts)
+	if size <= 0 {
+		return nil
+	}
+
+	type rangeJob struct {
+		offset, length int64
+	}
+	jobs := make([]rangeJob, 0, size/opts.PartSize+1)
+	for offset := int64(0); offset < size; offset += opts.PartSize {
+		length := opts.PartSize
+		if offset+length > size {
+			length = size - offset
+		}
+		jobs = append(jobs, rangeJob{offset: offset, length: length})
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, opts.Concurrency)
+	for _, j := range jobs {
+		mu.Lock()
+		err := firstErr
+		mu.Unlock()
+		if err != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(j rangeJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			rc, err := getRangeFn(ctx, j.offset, j.length)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			defer rc.Close()
+			buf := make([]byte, j.length)
+			if _, err := io.ReadFull(rc, buf); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			if _, err := w.WriteAt(buf, j.offset); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(j)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// rateLimitedReadCloser wraps an io.ReadCloser and throttles Read to the given bandwidth limit.
+type rateLimitedReadCloser struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedReadCloser throttles rc to limiter's configured bytes/sec, or returns rc
+// unchanged if limiter is nil.
+func NewRateLimitedReadCloser(ctx context.Context, rc io.ReadCloser, limiter *rate.Limiter) io.ReadCloser {
+	if limiter == nil {
+		return rc
+	}
+	return &rateLimitedReadCloser{ReadCloser: rc, ctx: ctx, limiter: limiter}
+}
+
+func (r *rateLimitedReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		burst := r.limiter.Burst()
+		for remaining := n; remaining > 0; {
+			take := remaining
+			if burst > 0 && take > burst {
+				take = burst
+			}
+			if werr := r.limiter.WaitN(r.ctx, take); werr != nil {
+				return n, werr
+			}
+			remaining -= take
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"mosn.io/layotto/components/file"
+)
+
+func TestRangeHeader(t *testing.T) {
+	cases := []struct {
+		offset, length int64
+		want            string
+	}{
+		{0, 100, "bytes=0-99"},
+		{50, 50, "bytes=50-99"},
+		{10, -1, "bytes=10-"},
+	}
+	for _, c := range cases {
+		if got := RangeHeader(c.offset, c.length); got != c.want {
+			t.Errorf("RangeHeader(%d, %d) = %q, want %q", c.offset, c.length, got, c.want)
+		}
+	}
+}
+
+// fakeWriterAt is a simple in-memory io.WriterAt for verifying ParallelGet writes each range at
+// the right offset.
+type fakeWriterAt struct {
+	buf []byte
+}
+
+func (w *fakeWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if need := int(off) + len(p); need > len(w.buf) {
+		grown := make([]byte, need)
+		copy(grown, w.buf)
+		w.buf = grown
+	}
+	copy(w.buf[off:], p)
+	return len(p), nil
+}
+
+func TestParallelGetSplitsIntoExpectedRanges(t *testing.T) {
+	const size = int64(25)
+	want := bytes.Repeat([]byte("x"), int(size))
+	w := &fakeWriterAt{}
+
+	err := ParallelGet(context.Background(), size, w, &file.ParallelGetOptions{PartSize: 10, Concurrency: 2},
+		func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+			if offset+length > size {
+				t.Fatalf("range [%d, %d) exceeds object size %d", offset, offset+length, size)
+			}
+			return ioutil.NopCloser(bytes.NewReader(want[offset : offset+length])), nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("ParallelGet failed: %v", err)
+	}
+	if !bytes.Equal(w.buf, want) {
+		t.Fatalf("ParallelGet wrote %q, want %q", w.buf, want)
+	}
+}
+
+func TestParallelGetPropagatesRangeError(t *testing.T) {
+	w := &fakeWriterAt{}
+	err := ParallelGet(context.Background(), 20, w, &file.ParallelGetOptions{PartSize: 10, Concurrency: 2},
+		func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+			return nil, errors.New("simulated range fetch error")
+		},
+	)
+	if err == nil {
+		t.Fatal("expected ParallelGet to return an error when every range fetch fails")
+	}
+}
+
+func TestParallelGetNoopOnZeroSize(t *testing.T) {
+	w := &fakeWriterAt{}
+	err := ParallelGet(context.Background(), 0, w, nil,
+		func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+			t.Fatal("getRangeFn should not be called for a zero-size object")
+			return nil, nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("expected no error for a zero-size object, got %v", err)
+	}
+}
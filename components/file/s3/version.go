@@ -0,0 +1,39 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3
+
+import "strings"
+
+// versionMarkerSep joins a key marker and version ID marker into the single string
+// file.ListRequest/file.ListResp pass through file.ListRequest.Marker/file.ListResp.Marker when
+// WithVersions is set, since ListObjectVersions needs both to paginate within a key's versions.
+const versionMarkerSep = "\x00"
+
+// JoinVersionMarker packs a key marker and version ID marker for a version-aware List page.
+func JoinVersionMarker(keyMarker, versionIDMarker string) string {
+	if keyMarker == "" && versionIDMarker == "" {
+		return ""
+	}
+	return keyMarker + versionMarkerSep + versionIDMarker
+}
+
+// SplitVersionMarker unpacks a marker produced by JoinVersionMarker back into its key marker and
+// version ID marker. A plain (non-version) marker is returned as the key marker alone.
+func SplitVersionMarker(marker string) (keyMarker, versionIDMarker string) {
+	keyMarker, versionIDMarker, _ = strings.Cut(marker, versionMarkerSep)
+	return keyMarker, versionIDMarker
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wkfs ("well-known filesystem") provides an fs.FS-style abstraction over the
+// components/file backends, so callers can consume remote object storage - or any other
+// registered backend - through a stdlib-shaped Open/Create/Stat/ReadDir/Remove API instead
+// of knowing about buckets, keys and the underlying SDK.
+package wkfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"mosn.io/layotto/components/file"
+)
+
+// largeWriteThreshold is the buffered-write size above which Close uses the backend's
+// multipart PutLarge instead of a single Put.
+const largeWriteThreshold = 32 * 1024 * 1024
+
+var (
+	mu       sync.RWMutex
+	backends = make(map[string]file.File)
+)
+
+// Register associates a scheme prefix (e.g. "s3://", "oss://", "local://") with the file.File
+// backend that should serve paths under it. Call this during component wiring, before any wkfs
+// path is opened. Registering the same scheme twice replaces the previous backend.
+func Register(scheme string, backend file.File) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[scheme] = backend
+}
+
+// resolve finds the backend registered for path's scheme and returns the path with the scheme
+// prefix stripped, in the bucket/key shape the file.File backends expect.
+func resolve(path string) (file.File, string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for scheme, backend := range backends {
+		if strings.HasPrefix(path, scheme) {
+			return backend, strings.TrimPrefix(path, scheme), nil
+		}
+	}
+	return nil, "", fmt.Errorf("wkfs: no backend registered for path %q", path)
+}
+
+// File is a remote object opened through wkfs. It satisfies io.Reader, io.Writer, io.Seeker and
+// io.Closer so a backend object can be consumed with a stdlib-shaped API.
+type File struct {
+	ctx     context.Context
+	backend file.File
+	name    string // path with the scheme prefix stripped
+
+	rc     io.ReadCloser
+	offset int64
+
+	buf      bytes.Buffer
+	writable bool
+}
+
+// Open opens path for reading. path must start with a scheme registered via Register.
+func Open(path string) (*File, error) {
+	return OpenContext(context.Background(), path)
+}
+
+// OpenContext is Open with a caller-supplied context.
+func OpenContext(ctx context.Context, path string) (*File, error) {
+	backend, name, err := resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{ctx: ctx, backend: backend, name: name}, nil
+}
+
+// Create opens path for writing. Bytes written are buffered locally and flushed to the backend
+// on Close, via PutLarge for payloads above largeWriteThreshold and Put otherwise.
+func Create(path string) (*File, error) {
+	return CreateContext(context.Background(), path)
+}
+
+// CreateContext is Create with a caller-supplied context.
+func CreateContext(ctx context.Context, path string) (*File, error) {
+	backend, name, err := resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{ctx: ctx, backend: backend, name: name, writable: true}, nil
+}
+
+func (f *File) ensureReader() error {
+	if f.rc != nil {
+		return nil
+	}
+	rc, err := f.backend.Get(f.ctx, &file.GetFileStu{FileName: f.name})
+	if err != nil {
+		return err
+	}
+	f.rc = rc
+	return nil
+}
+
+// Read implements io.Reader.
+func (f *File) Read(p []byte) (int, error) {
+	if f.writable {
+		return 0, fmt.Errorf("wkfs: file %q was opened for writing", f.name)
+	}
+	if err := f.ensureReader(); err != nil {
+		return 0, err
+	}
+	n, err := f.rc.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+// Write implements io.Writer. Bytes are buffered and only sent to the backend on Close.
+func (f *File) Write(p []byte) (int, error) {
+	if !f.writable {
+		return 0, fmt.Errorf("wkfs: file %q was opened for reading", f.name)
+	}
+	return f.buf.Write(p)
+}
+
+// Seek implements io.Seeker. It reopens the backend stream with GetRange at the target offset,
+// so a seek costs a ranged read of what's left to read rather than downloading (and discarding)
+// everything up to the target offset.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.writable {
+		return 0, fmt.Errorf("wkfs: file %q was opened for writing", f.name)
+	}
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = f.offset + offset
+	case io.SeekEnd:
+		meta, err := f.backend.Stat(f.ctx, &file.FileMetaRequest{FileName: f.name})
+		if err != nil {
+			return 0, err
+		}
+		target = meta.Size + offset
+	default:
+		return 0, fmt.Errorf("wkfs: invalid whence %d", whence)
+	}
+	if target == f.offset && f.rc != nil {
+		return f.offset, nil
+	}
+	if f.rc != nil {
+		f.rc.Close()
+		f.rc = nil
+	}
+	rc, err := f.backend.GetRange(f.ctx, &file.RangeGetReq{FileName: f.name, Offset: target, Length: -1})
+	if err != nil {
+		return 0, err
+	}
+	f.rc = rc
+	f.offset = target
+	return f.offset, nil
+}
+
+// Close implements io.Closer. For files opened with Create, it flushes the buffered bytes to
+// the backend.
+func (f *File) Close() error {
+	if f.rc != nil {
+		err := f.rc.Close()
+		f.rc = nil
+		return err
+	}
+	if !f.writable {
+		return nil
+	}
+	st := &file.PutFileStu{FileName: f.name, DataStream: bytes.NewReader(f.buf.Bytes())}
+	if f.buf.Len() > largeWriteThreshold {
+		return f.backend.PutLarge(f.ctx, st, nil)
+	}
+	return f.backend.Put(f.ctx, st)
+}
+
+// Stat returns size/mtime metadata for path.
+func Stat(path string) (*file.FileMetaResp, error) {
+	backend, name, err := resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Stat(context.Background(), &file.FileMetaRequest{FileName: name})
+}
+
+// ReadDir lists every object under a directory/prefix, paging through backend.List until the
+// listing is exhausted rather than returning just the first page. Objects in nested
+// "subdirectories" are included; the listing is not limited to immediate children.
+func ReadDir(path string) ([]*file.FilesInfo, error) {
+	backend, name, err := resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []*file.FilesInfo
+	marker := ""
+	for {
+		resp, err := backend.List(context.Background(), &file.ListRequest{DirectoryName: name, Marker: marker})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, resp.Files...)
+		if !resp.IsTruncated {
+			return files, nil
+		}
+		marker = resp.Marker
+	}
+}
+
+// Remove deletes path.
+func Remove(path string) error {
+	backend, name, err := resolve(path)
+	if err != nil {
+		return err
+	}
+	return backend.Del(context.Background(), &file.DelRequest{FileName: name})
+}
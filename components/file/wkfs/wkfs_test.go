@@ -0,0 +1,279 @@
+/*
+ * Copyright 2021 Layotto Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wkfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"mosn.io/layotto/components/file"
+)
+
+// fakeBackend is a minimal file.File used to exercise wkfs's pure logic without a real backend.
+// Methods wkfs doesn't touch panic if called, so an unexpected call fails the test loudly.
+type fakeBackend struct {
+	size int64
+
+	getRangeOffsets []int64
+
+	putCalls      int
+	putLargeCalls int
+	lastPutData   []byte
+
+	listPages [][]*file.FilesInfo
+	listCalls int
+}
+
+func (f *fakeBackend) Init(ctx context.Context, config *file.FileConfig) error { panic("not used") }
+func (f *fakeBackend) Put(ctx context.Context, req *file.PutFileStu) error {
+	f.putCalls++
+	data, err := ioutil.ReadAll(req.DataStream)
+	if err != nil {
+		return err
+	}
+	f.lastPutData = data
+	return nil
+}
+func (f *fakeBackend) Get(ctx context.Context, req *file.GetFileStu) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(nil)), nil
+}
+func (f *fakeBackend) List(ctx context.Context, req *file.ListRequest) (*file.ListResp, error) {
+	idx := f.listCalls
+	f.listCalls++
+	if idx >= len(f.listPages) {
+		return &file.ListResp{}, nil
+	}
+	resp := &file.ListResp{Files: f.listPages[idx], IsTruncated: idx < len(f.listPages)-1}
+	if resp.IsTruncated {
+		resp.Marker = fmt.Sprintf("page-%d", idx+1)
+	}
+	return resp, nil
+}
+func (f *fakeBackend) Del(ctx context.Context, req *file.DelRequest) error { panic("not used") }
+func (f *fakeBackend) Stat(ctx context.Context, req *file.FileMetaRequest) (*file.FileMetaResp, error) {
+	return &file.FileMetaResp{Size: f.size}, nil
+}
+func (f *fakeBackend) InitMultipart(ctx context.Context, req *file.InitMultipartReq) (string, error) {
+	panic("not used")
+}
+func (f *fakeBackend) UploadPart(ctx context.Context, req *file.UploadPartReq) (*file.PartInfo, error) {
+	panic("not used")
+}
+func (f *fakeBackend) CompleteMultipart(ctx context.Context, req *file.CompleteMultipartReq) error {
+	panic("not used")
+}
+func (f *fakeBackend) AbortMultipart(ctx context.Context, req *file.AbortMultipartReq) error {
+	panic("not used")
+}
+func (f *fakeBackend) ListParts(ctx context.Context, req *file.ListPartsReq) ([]*file.PartInfo, error) {
+	panic("not used")
+}
+func (f *fakeBackend) PutLarge(ctx context.Context, req *file.PutFileStu, opts *file.PutLargeOptions) error {
+	f.putLargeCalls++
+	data, err := ioutil.ReadAll(req.DataStream)
+	if err != nil {
+		return err
+	}
+	f.lastPutData = data
+	return nil
+}
+func (f *fakeBackend) SignURL(ctx context.Context, req *file.SignURLRequest) (*file.SignURLResponse, error) {
+	panic("not used")
+}
+func (f *fakeBackend) SignPostPolicy(ctx context.Context, req *file.PostPolicyRequest) (*file.PostPolicyResponse, error) {
+	panic("not used")
+}
+func (f *fakeBackend) RestoreVersion(ctx context.Context, req *file.RestoreVersionReq) error {
+	panic("not used")
+}
+func (f *fakeBackend) Copy(ctx context.Context, req *file.CopyRequest) error     { panic("not used") }
+func (f *fakeBackend) Rename(ctx context.Context, req *file.RenameRequest) error { panic("not used") }
+func (f *fakeBackend) GetRange(ctx context.Context, req *file.RangeGetReq) (io.ReadCloser, error) {
+	f.getRangeOffsets = append(f.getRangeOffsets, req.Offset)
+	return ioutil.NopCloser(bytes.NewReader(nil)), nil
+}
+func (f *fakeBackend) ParallelGet(ctx context.Context, fileName string, w io.WriterAt, metadata map[string]string, opts *file.ParallelGetOptions) error {
+	panic("not used")
+}
+
+func TestSeekStart(t *testing.T) {
+	backend := &fakeBackend{size: 100}
+	f := &File{ctx: context.Background(), backend: backend, name: "obj"}
+	got, err := f.Seek(10, io.SeekStart)
+	if err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if got != 10 || f.offset != 10 {
+		t.Fatalf("Seek(10, SeekStart) = %d, offset = %d, want 10", got, f.offset)
+	}
+	if want := []int64{10}; !equalInt64s(backend.getRangeOffsets, want) {
+		t.Fatalf("GetRange called with offsets %v, want %v", backend.getRangeOffsets, want)
+	}
+}
+
+func TestSeekCurrent(t *testing.T) {
+	backend := &fakeBackend{size: 100}
+	f := &File{ctx: context.Background(), backend: backend, name: "obj", offset: 20}
+	got, err := f.Seek(5, io.SeekCurrent)
+	if err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if got != 25 || f.offset != 25 {
+		t.Fatalf("Seek(5, SeekCurrent) from offset 20 = %d, want 25", got)
+	}
+}
+
+func TestSeekEnd(t *testing.T) {
+	backend := &fakeBackend{size: 100}
+	f := &File{ctx: context.Background(), backend: backend, name: "obj"}
+	got, err := f.Seek(-10, io.SeekEnd)
+	if err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if got != 90 || f.offset != 90 {
+		t.Fatalf("Seek(-10, SeekEnd) with size 100 = %d, want 90", got)
+	}
+}
+
+func TestSeekInvalidWhence(t *testing.T) {
+	backend := &fakeBackend{size: 100}
+	f := &File{ctx: context.Background(), backend: backend, name: "obj"}
+	if _, err := f.Seek(0, 99); err == nil {
+		t.Fatal("expected an error for an invalid whence value")
+	}
+}
+
+func TestSeekOnWritableFileErrors(t *testing.T) {
+	f := &File{ctx: context.Background(), backend: &fakeBackend{}, name: "obj", writable: true}
+	if _, err := f.Seek(0, io.SeekStart); err == nil {
+		t.Fatal("expected Seek on a writable file to error")
+	}
+}
+
+func TestSeekToCurrentOffsetSkipsReopen(t *testing.T) {
+	backend := &fakeBackend{size: 100}
+	f := &File{ctx: context.Background(), backend: backend, name: "obj"}
+	if _, err := f.Seek(10, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if _, err := f.Seek(10, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if len(backend.getRangeOffsets) != 1 {
+		t.Fatalf("re-seeking to the current offset should not reopen the stream, got %d GetRange calls", len(backend.getRangeOffsets))
+	}
+}
+
+func TestCloseFlushesSmallWriteWithPut(t *testing.T) {
+	backend := &fakeBackend{}
+	f := &File{ctx: context.Background(), backend: backend, name: "obj", writable: true}
+	data := []byte("hello world")
+	if _, err := f.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if backend.putCalls != 1 || backend.putLargeCalls != 0 {
+		t.Fatalf("Close on a small write should call Put once, got putCalls=%d putLargeCalls=%d", backend.putCalls, backend.putLargeCalls)
+	}
+	if !bytes.Equal(backend.lastPutData, data) {
+		t.Fatalf("Put received %q, want %q", backend.lastPutData, data)
+	}
+}
+
+func TestCloseFlushesLargeWriteWithPutLarge(t *testing.T) {
+	backend := &fakeBackend{}
+	f := &File{ctx: context.Background(), backend: backend, name: "obj", writable: true}
+	data := bytes.Repeat([]byte("x"), largeWriteThreshold+1)
+	if _, err := f.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if backend.putCalls != 0 || backend.putLargeCalls != 1 {
+		t.Fatalf("Close on a write above largeWriteThreshold should call PutLarge once, got putCalls=%d putLargeCalls=%d", backend.putCalls, backend.putLargeCalls)
+	}
+}
+
+func TestCloseOnReadOnlyFileIsNoop(t *testing.T) {
+	backend := &fakeBackend{}
+	f := &File{ctx: context.Background(), backend: backend, name: "obj"}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close on a never-read file should be a no-op, got err: %v", err)
+	}
+	if backend.putCalls != 0 || backend.putLargeCalls != 0 {
+		t.Fatal("Close on a read-only file must not flush anything")
+	}
+}
+
+func TestRegisterAndResolveDispatchByScheme(t *testing.T) {
+	backend := &fakeBackend{}
+	Register("wkfstest://", backend)
+	defer Register("wkfstest://", nil)
+
+	got, name, err := resolve("wkfstest://bucket/key")
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if got != file.File(backend) || name != "bucket/key" {
+		t.Fatalf("resolve(%q) = (%v, %q), want (backend, \"bucket/key\")", "wkfstest://bucket/key", got, name)
+	}
+}
+
+func TestResolveUnregisteredSchemeErrors(t *testing.T) {
+	if _, _, err := resolve("nosuchscheme://bucket/key"); err == nil {
+		t.Fatal("expected resolve to error for an unregistered scheme")
+	}
+}
+
+func TestReadDirPagesThroughTruncatedListings(t *testing.T) {
+	backend := &fakeBackend{listPages: [][]*file.FilesInfo{
+		{{FileName: "a"}, {FileName: "b"}},
+		{{FileName: "c"}},
+	}}
+	Register("wkfstest://", backend)
+	defer Register("wkfstest://", nil)
+
+	files, err := ReadDir("wkfstest://bucket/prefix")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("ReadDir returned %d files across pages, want 3", len(files))
+	}
+	if backend.listCalls != 2 {
+		t.Fatalf("ReadDir made %d List calls, want 2 (one per page)", backend.listCalls)
+	}
+}
+
+func equalInt64s(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}